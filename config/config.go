@@ -0,0 +1,56 @@
+// Package config lets a DynamicTimeout be configured from YAML/TOML/JSON
+// instead of Go code, for the common case of setting the min/max timeout,
+// history size and quantile policy.
+package config
+
+import (
+	"errors"
+
+	dynamic_timeout "github.com/HFN/dynamic-timeout"
+)
+
+// Config holds the settings for New(...), with duration fields expressed in
+// the human readable form accepted by Duration.
+type Config struct {
+	MinTimeout Duration `json:"minTimeout" yaml:"minTimeout" toml:"min_timeout"`
+	MaxTimeout Duration `json:"maxTimeout" yaml:"maxTimeout" toml:"max_timeout"`
+	MaxHistory int      `json:"maxHistory" yaml:"maxHistory" toml:"max_history"`
+	Quantile   float64  `json:"quantile" yaml:"quantile" toml:"quantile"`
+	Multiplier float64  `json:"multiplier" yaml:"multiplier" toml:"multiplier"`
+}
+
+// Options materialises Config into the Option slice expected by
+// dynamic_timeout.New(...). Zero-valued fields are left at New's defaults,
+// except Quantile/Multiplier, which default to 0.95/3 (the same "3 * p95"
+// policy as the package default) the moment either one is set.
+func (c Config) Options() ([]dynamic_timeout.Option, error) {
+	if c.Quantile < 0 || c.Quantile > 1 {
+		return nil, errors.New("quantile must be between 0 and 1")
+	}
+
+	var options []dynamic_timeout.Option
+
+	if c.MinTimeout.Duration > 0 {
+		options = append(options, dynamic_timeout.WithMinTimeout(c.MinTimeout.Duration))
+	}
+	if c.MaxTimeout.Duration > 0 {
+		options = append(options, dynamic_timeout.WithMaxTimeout(c.MaxTimeout.Duration))
+	}
+	if c.MaxHistory > 0 {
+		options = append(options, dynamic_timeout.WithMaxHistory(c.MaxHistory))
+	}
+	if c.Quantile > 0 || c.Multiplier > 0 {
+		quantile := c.Quantile
+		if quantile == 0 {
+			quantile = 0.95
+		}
+		multiplier := c.Multiplier
+		if multiplier == 0 {
+			multiplier = 3
+		}
+
+		options = append(options, dynamic_timeout.WithTimeoutFunc(dynamic_timeout.QuantileTimeoutFunc(quantile, multiplier)))
+	}
+
+	return options, nil
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"encoding/json"
+	dynamic_timeout "github.com/HFN/dynamic-timeout"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestDurationUnmarshalJSONFromString(t *testing.T) {
+	var d Duration
+	assert.NoError(t, json.Unmarshal([]byte(`"1.5s"`), &d))
+	assert.EqualValues(t, 1500*time.Millisecond, d.Duration)
+}
+
+func TestDurationUnmarshalJSONFromNumber(t *testing.T) {
+	var d Duration
+	assert.NoError(t, json.Unmarshal([]byte(`250`), &d))
+	assert.EqualValues(t, 250*time.Nanosecond, d.Duration)
+}
+
+func TestConfigUnmarshalJSON(t *testing.T) {
+	var c Config
+	err := json.Unmarshal([]byte(`{
+		"minTimeout": "10ms",
+		"maxTimeout": "500ms",
+		"maxHistory": 50,
+		"quantile": 0.99,
+		"multiplier": 2
+	}`), &c)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 10*time.Millisecond, c.MinTimeout.Duration)
+	assert.EqualValues(t, 500*time.Millisecond, c.MaxTimeout.Duration)
+	assert.EqualValues(t, 50, c.MaxHistory)
+	assert.EqualValues(t, 0.99, c.Quantile)
+	assert.EqualValues(t, 2, c.Multiplier)
+}
+
+func TestConfigOptionsAppliesDefaultsAndBuilds(t *testing.T) {
+	c := Config{
+		MinTimeout: Duration{10 * time.Millisecond},
+		MaxTimeout: Duration{500 * time.Millisecond},
+		MaxHistory: 50,
+	}
+
+	options, err := c.Options()
+	assert.NoError(t, err)
+
+	dt, err := dynamic_timeout.New(options...)
+	assert.NoError(t, err)
+	assert.NotNil(t, dt)
+}
+
+func TestConfigOptionsRejectsInvalidQuantile(t *testing.T) {
+	c := Config{Quantile: 1.5}
+
+	_, err := c.Options()
+	assert.Error(t, err)
+}
@@ -0,0 +1,52 @@
+package config
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Duration wraps time.Duration so it can be unmarshaled from the human
+// readable strings users write in YAML/TOML/JSON config files, e.g. "250ms"
+// or "1.5s", instead of a raw integer count of nanoseconds.
+type Duration struct {
+	time.Duration
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, used by YAML/TOML
+// decoders (and by encoding/json for non-string-keyed values) that support it.
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+
+	d.Duration = parsed
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler.
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.Duration.String()), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It accepts both a duration
+// string ("250ms") and a bare number, which is interpreted as nanoseconds.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var asString string
+	if err := json.Unmarshal(data, &asString); err == nil {
+		return d.UnmarshalText([]byte(asString))
+	}
+
+	var asNumber float64
+	if err := json.Unmarshal(data, &asNumber); err != nil {
+		return err
+	}
+
+	d.Duration = time.Duration(asNumber)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Duration.String())
+}
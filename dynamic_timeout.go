@@ -4,6 +4,7 @@ import (
 	"errors"
 	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -24,10 +25,25 @@ var (
 type (
 	// DynamicTimeout represents a struct responsible for maintaining response time and calculating a dynamic timeout
 	DynamicTimeout struct {
-		minTimeout  time.Duration
-		maxTimeout  time.Duration
-		maxHistory  int
-		timeoutFunc TimeOutFunc
+		minTimeout           time.Duration
+		maxTimeout           time.Duration
+		maxHistory           int
+		timeoutFunc          TimeOutFunc
+		estimator            Estimator
+		estimatorTimeoutFunc EstimatorTimeoutFunc
+		timeWindow           time.Duration
+		timeWindowSet        bool
+		windowStore          *timeWindowedStore
+		timeoutFuncCtx       TimeOutFuncCtx
+		observer             Observer
+
+		// observationCount tracks how many times Observe has been called,
+		// regardless of which backing store is in use. The estimator and
+		// time-window stores have no built-in notion of "empty" the way the
+		// history slice does (it's pre-filled with maxTimeout), so GetTimeout
+		// consults this instead of asking the store, to keep a cold instance
+		// just as conservative as the default path.
+		observationCount uint64
 
 		responseTimeHistory []time.Duration
 		currentHistoryIndex int
@@ -41,6 +57,19 @@ type (
 
 	// Option is the type of constructor options for New(...)
 	Option func (dt *DynamicTimeout)
+
+	// Observer receives callbacks on every Observe and GetTimeout call, for
+	// instrumentation purposes (metrics, logging, ...). Implementations must
+	// be safe for concurrent use and should not block.
+	Observer interface {
+		// OnObserve is called with the responseTime passed to Observe.
+		OnObserve(responseTime time.Duration)
+		// OnGetTimeout is called with the timeout computed by TimeOutFunc (or
+		// EstimatorTimeoutFunc/TimeOutFuncCtx) and the value actually returned
+		// by GetTimeout after clamping it to [minTimeout, maxTimeout]. While an
+		// Estimator or time window has no observations yet, both are maxTimeout.
+		OnGetTimeout(computed time.Duration, clamped time.Duration)
+	}
 )
 
 // New returns a new DynamicTimeout
@@ -72,16 +101,49 @@ func New(options ...Option) (*DynamicTimeout, error) {
 		return nil, errors.New("timeoutFunc is nil")
 	}
 
-	dt.responseTimeHistory = make([]time.Duration, dt.maxHistory, dt.maxHistory)
-	for index := range dt.responseTimeHistory {
-		dt.responseTimeHistory[index] = dt.maxTimeout
+	if dt.estimator != nil && dt.estimatorTimeoutFunc == nil {
+		return nil, errors.New("estimatorTimeoutFunc is nil")
+	}
+	if dt.timeWindowSet && dt.timeWindow <= 0 {
+		return nil, errors.New("timeWindow is not positive")
+	}
+	if dt.timeWindowSet && dt.timeoutFuncCtx == nil {
+		return nil, errors.New("timeoutFuncCtx is nil")
+	}
+	if dt.estimator != nil && dt.timeWindowSet {
+		return nil, errors.New("WithEstimator and WithTimeWindow are mutually exclusive")
+	}
+
+	if dt.timeWindowSet {
+		dt.windowStore = newTimeWindowedStore(dt.timeWindow, defaultTimeWindowBuckets, dt.minTimeout, dt.maxTimeout)
+	}
+
+	if dt.estimator == nil && dt.windowStore == nil {
+		dt.responseTimeHistory = make([]time.Duration, dt.maxHistory, dt.maxHistory)
+		for index := range dt.responseTimeHistory {
+			dt.responseTimeHistory[index] = dt.maxTimeout
+		}
+		dt.currentHistoryIndex = 0
 	}
-	dt.currentHistoryIndex = 0
 	dt.lock = &sync.Mutex{}
 
 	return dt, nil
 }
 
+// QuantileTimeoutFunc returns a TimeOutFunc that computes
+// multiplier * quantile(q) over the history, e.g. QuantileTimeoutFunc(0.95, 3)
+// for the same "3 * p95" policy as defaultTimeoutFunc.
+func QuantileTimeoutFunc(q float64, multiplier float64) TimeOutFunc {
+	return func(responseTimeHistory []time.Duration) time.Duration {
+		sorted := make([]time.Duration, len(responseTimeHistory))
+		copy(sorted, responseTimeHistory)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+		index := int(q * float64(len(sorted)-1))
+		return time.Duration(multiplier * float64(sorted[index]))
+	}
+}
+
 // WithMinTimeout sets minimum allowed timeout
 func WithMinTimeout(minTimeout time.Duration) Option {
 	return func(dt *DynamicTimeout) {
@@ -110,33 +172,104 @@ func WithTimeoutFunc(timeoutFunc TimeOutFunc) Option {
 	}
 }
 
-// Observe observes the given responseTime and stores it inside history
+// WithEstimator switches the DynamicTimeout from the fixed-size history slice
+// to the given Estimator, which is responsible for maintaining its own
+// backing store and answering quantile queries. The history-based
+// TimeOutFunc is not used once an Estimator is set; use
+// WithEstimatorTimeoutFunc to customise how the estimated quantile maps to a
+// timeout. Defaults to 3 * p95, matching the zero-value behaviour of
+// defaultTimeoutFunc.
+func WithEstimator(estimator Estimator) Option {
+	return func(dt *DynamicTimeout) {
+		dt.estimator = estimator
+		if dt.estimatorTimeoutFunc == nil {
+			dt.estimatorTimeoutFunc = EstimatorQuantileTimeoutFunc(0.95, 3)
+		}
+	}
+}
+
+// WithEstimatorTimeoutFunc sets a custom EstimatorTimeoutFunc to calculate
+// timeout from the Estimator set via WithEstimator.
+func WithEstimatorTimeoutFunc(estimatorTimeoutFunc EstimatorTimeoutFunc) Option {
+	return func(dt *DynamicTimeout) {
+		dt.estimatorTimeoutFunc = estimatorTimeoutFunc
+	}
+}
+
+// WithObserver sets an Observer to receive callbacks on every Observe and
+// GetTimeout call.
+func WithObserver(observer Observer) Option {
+	return func(dt *DynamicTimeout) {
+		dt.observer = observer
+	}
+}
+
+// Observe observes the given responseTime and stores it inside history, or
+// feeds it to the configured Estimator if one was set via WithEstimator, or
+// records it in the time window if one was set via WithTimeWindow.
 // It is strongly recommended observing responseTime of all successful and timed out requests
 func (dt *DynamicTimeout) Observe(responseTime time.Duration) {
-	dt.lock.Lock()
+	switch {
+	case dt.windowStore != nil:
+		dt.windowStore.observe(responseTime)
+	case dt.estimator != nil:
+		dt.lock.Lock()
+		dt.estimator.Observe(responseTime)
+		dt.lock.Unlock()
+	default:
+		dt.lock.Lock()
+		dt.responseTimeHistory[dt.currentHistoryIndex] = responseTime
+		dt.currentHistoryIndex = (dt.currentHistoryIndex + 1) % dt.maxHistory
+		dt.lock.Unlock()
+	}
 
-	dt.responseTimeHistory[dt.currentHistoryIndex] = responseTime
-	dt.currentHistoryIndex = (dt.currentHistoryIndex + 1) % dt.maxHistory
+	atomic.AddUint64(&dt.observationCount, 1)
 
-	dt.lock.Unlock()
+	if dt.observer != nil {
+		dt.observer.OnObserve(responseTime)
+	}
 }
 
-// GetTimeout calls TimeOutFunc to return an appropriate timeout
+// GetTimeout calls TimeOutFunc (or EstimatorTimeoutFunc/TimeOutFuncCtx, if an
+// Estimator or a time window was configured) to return an appropriate timeout.
+// Like the history-based path, which starts pre-filled with maxTimeout, a
+// fresh Estimator or time window with no observations yet returns maxTimeout
+// rather than letting a zero-value quantile collapse to minTimeout.
 func (dt *DynamicTimeout) GetTimeout() time.Duration {
-	dt.lock.Lock()
+	if (dt.windowStore != nil || dt.estimator != nil) && atomic.LoadUint64(&dt.observationCount) == 0 {
+		if dt.observer != nil {
+			dt.observer.OnGetTimeout(dt.maxTimeout, dt.maxTimeout)
+		}
+		return dt.maxTimeout
+	}
 
-	responseTimeHistory := make([]time.Duration, dt.maxHistory)
-	copy(responseTimeHistory, dt.responseTimeHistory)
+	var computed time.Duration
 
-	dt.lock.Unlock()
+	switch {
+	case dt.windowStore != nil:
+		computed = dt.timeoutFuncCtx(dt.windowStore.samples())
+	case dt.estimator != nil:
+		computed = dt.estimatorTimeoutFunc(dt.estimator)
+	default:
+		dt.lock.Lock()
+		responseTimeHistory := make([]time.Duration, dt.maxHistory)
+		copy(responseTimeHistory, dt.responseTimeHistory)
+		dt.lock.Unlock()
 
-	timeout := dt.timeoutFunc(responseTimeHistory)
-	if timeout < dt.minTimeout {
-		return dt.minTimeout
+		computed = dt.timeoutFunc(responseTimeHistory)
 	}
-	if timeout > dt.maxTimeout {
-		return dt.maxTimeout
+
+	clamped := computed
+	if clamped < dt.minTimeout {
+		clamped = dt.minTimeout
+	}
+	if clamped > dt.maxTimeout {
+		clamped = dt.maxTimeout
+	}
+
+	if dt.observer != nil {
+		dt.observer.OnGetTimeout(computed, clamped)
 	}
 
-	return timeout
+	return clamped
 }
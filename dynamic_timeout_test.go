@@ -61,3 +61,11 @@ func TestDefaultTimeoutFunc(t *testing.T) {
 
 	assert.EqualValues(t, 285, timeout)
 }
+
+func TestQuantileTimeoutFunc(t *testing.T) {
+	timeout := QuantileTimeoutFunc(0.95, 3)([]time.Duration{
+		0, 5, 10, 15, 20, 25, 30, 35, 40, 45, 50, 55, 60, 65, 70, 75, 80, 85, 90, 95, 100,
+	})
+
+	assert.EqualValues(t, 285, timeout)
+}
@@ -0,0 +1,31 @@
+package dynamic_timeout
+
+import "time"
+
+type (
+	// Estimator maintains an online estimate of a response time distribution
+	// without requiring the caller to own and sort a history slice. It is set
+	// via WithEstimator as an opt-in, O(1)-per-observation alternative to the
+	// default history-based TimeOutFunc.
+	Estimator interface {
+		// Observe records a single response time.
+		Observe(responseTime time.Duration)
+		// Quantile returns the estimated value at quantile q, where q is in [0, 1].
+		Quantile(q float64) time.Duration
+		// Reset discards all observations, returning the Estimator to its initial state.
+		Reset()
+	}
+
+	// EstimatorTimeoutFunc represents a function which calculates a timeout
+	// from an Estimator, analogous to TimeOutFunc for the history-based store.
+	EstimatorTimeoutFunc func(estimator Estimator) time.Duration
+)
+
+// EstimatorQuantileTimeoutFunc returns an EstimatorTimeoutFunc that computes
+// multiplier * quantile(q) from the Estimator, e.g.
+// EstimatorQuantileTimeoutFunc(0.95, 3) for the default "3 * p95" policy.
+func EstimatorQuantileTimeoutFunc(q float64, multiplier float64) EstimatorTimeoutFunc {
+	return func(estimator Estimator) time.Duration {
+		return time.Duration(multiplier * float64(estimator.Quantile(q)))
+	}
+}
@@ -0,0 +1,76 @@
+package dynamic_timeout
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestP2EstimatorQuantile(t *testing.T) {
+	e := NewP2Estimator(0.5)
+
+	for i := 1; i <= 100; i++ {
+		e.Observe(time.Duration(i))
+	}
+
+	median := e.Quantile(0.5)
+	assert.InDelta(t, 50, median, 5)
+}
+
+func TestP2EstimatorReset(t *testing.T) {
+	e := NewP2Estimator(0.95)
+
+	for i := 1; i <= 10; i++ {
+		e.Observe(time.Duration(i) * time.Second)
+	}
+
+	e.Reset()
+	assert.EqualValues(t, 0, e.Quantile(0.95))
+}
+
+func TestHistogramEstimatorQuantile(t *testing.T) {
+	e := NewHistogramEstimator(time.Microsecond, 10*time.Second)
+
+	for i := 1; i <= 100; i++ {
+		e.Observe(time.Duration(i) * time.Millisecond)
+	}
+
+	p95 := e.Quantile(0.95)
+	assert.InDelta(t, 95*time.Millisecond, p95, float64(5*time.Millisecond))
+}
+
+func TestHistogramEstimatorClampsOutOfRange(t *testing.T) {
+	e := NewHistogramEstimator(time.Microsecond, 10*time.Second)
+
+	e.Observe(0)
+	e.Observe(time.Minute)
+
+	assert.EqualValues(t, 10*time.Second, e.Quantile(1))
+}
+
+func TestDynamicTimeoutWithEstimatorGetTimeoutBeforeObserve(t *testing.T) {
+	dt, err := New(
+		WithMinTimeout(time.Millisecond),
+		WithMaxTimeout(time.Second),
+		WithEstimator(NewHistogramEstimator(time.Microsecond, 10*time.Second)),
+	)
+	assert.NoError(t, err)
+
+	assert.EqualValues(t, time.Second, dt.GetTimeout())
+}
+
+func TestDynamicTimeoutWithEstimator(t *testing.T) {
+	dt, err := New(
+		WithMinTimeout(time.Millisecond),
+		WithMaxTimeout(time.Second),
+		WithEstimator(NewHistogramEstimator(time.Microsecond, 10*time.Second)),
+	)
+	assert.NoError(t, err)
+
+	for i := 0; i < 100; i++ {
+		dt.Observe(10 * time.Millisecond)
+	}
+
+	timeout := dt.GetTimeout()
+	assert.InDelta(t, 30*time.Millisecond, timeout, float64(10*time.Millisecond))
+}
@@ -0,0 +1,92 @@
+// Package grpcdt wires DynamicTimeout into a gRPC client by deriving each
+// call's deadline from DynamicTimeout.GetTimeout and observing the actual
+// call duration back into it.
+package grpcdt
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	dynamic_timeout "github.com/HFN/dynamic-timeout"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryClientInterceptor returns a grpc.UnaryClientInterceptor that applies
+// dt.GetTimeout() as the deadline for every unary call, and calls
+// dt.Observe() with the elapsed call duration for both successful calls and
+// calls that hit that deadline.
+func UnaryClientInterceptor(dt *dynamic_timeout.DynamicTimeout) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx, cancel := context.WithTimeout(ctx, dt.GetTimeout())
+		defer cancel()
+
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		elapsed := time.Since(start)
+
+		if err == nil || status.Code(err) == codes.DeadlineExceeded {
+			dt.Observe(elapsed)
+		}
+
+		return err
+	}
+}
+
+// StreamClientInterceptor is like UnaryClientInterceptor, but for streaming
+// calls. Because a stream's duration is only known once the caller stops
+// reading from it, the call is observed when RecvMsg returns io.EOF or a
+// DeadlineExceeded error rather than when it is opened. A stream whose last
+// RecvMsg returns a message and no io.EOF follows (as in client-streaming
+// calls) is not observed, and its context is released only when the
+// deadline passes or the parent context ends.
+func StreamClientInterceptor(dt *dynamic_timeout.DynamicTimeout) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		ctx, cancel := context.WithTimeout(ctx, dt.GetTimeout())
+
+		start := time.Now()
+		stream, err := streamer(ctx, desc, cc, method, opts...)
+		if err != nil {
+			cancel()
+			if status.Code(err) == codes.DeadlineExceeded {
+				dt.Observe(time.Since(start))
+			}
+			return nil, err
+		}
+
+		return &timedClientStream{ClientStream: stream, dt: dt, start: start, cancel: cancel}, nil
+	}
+}
+
+// timedClientStream wraps a grpc.ClientStream to observe its total duration,
+// and release its deadline's context, exactly once when RecvMsg first
+// returns an error.
+type timedClientStream struct {
+	grpc.ClientStream
+
+	dt     *dynamic_timeout.DynamicTimeout
+	start  time.Time
+	cancel context.CancelFunc
+	once   sync.Once
+}
+
+func (s *timedClientStream) RecvMsg(m interface{}) error {
+	err := s.ClientStream.RecvMsg(m)
+	if err != nil {
+		s.finish(err)
+	}
+
+	return err
+}
+
+func (s *timedClientStream) finish(err error) {
+	s.once.Do(func() {
+		if err == io.EOF || status.Code(err) == codes.DeadlineExceeded {
+			s.dt.Observe(time.Since(s.start))
+		}
+		s.cancel()
+	})
+}
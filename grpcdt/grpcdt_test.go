@@ -0,0 +1,90 @@
+package grpcdt
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	dynamic_timeout "github.com/HFN/dynamic-timeout"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func dialHealthClient(t *testing.T, dt *dynamic_timeout.DynamicTimeout) (grpc_health_v1.HealthClient, func()) {
+	t.Helper()
+
+	listener := bufconn.Listen(1024 * 1024)
+
+	server := grpc.NewServer()
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
+	grpc_health_v1.RegisterHealthServer(server, healthServer)
+	go func() { _ = server.Serve(listener) }()
+
+	conn, err := grpc.DialContext(context.Background(), "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUnaryInterceptor(UnaryClientInterceptor(dt)),
+		grpc.WithStreamInterceptor(StreamClientInterceptor(dt)),
+	)
+	assert.NoError(t, err)
+
+	return grpc_health_v1.NewHealthClient(conn), func() {
+		_ = conn.Close()
+		server.Stop()
+	}
+}
+
+type countingObserver struct {
+	observed int32
+}
+
+func (o *countingObserver) OnObserve(time.Duration) {
+	atomic.AddInt32(&o.observed, 1)
+}
+
+func (o *countingObserver) OnGetTimeout(time.Duration, time.Duration) {}
+
+func TestUnaryClientInterceptorObservesSuccessfulCall(t *testing.T) {
+	observer := &countingObserver{}
+	dt, err := dynamic_timeout.New(
+		dynamic_timeout.WithMinTimeout(time.Millisecond),
+		dynamic_timeout.WithMaxTimeout(time.Second),
+		dynamic_timeout.WithObserver(observer),
+	)
+	assert.NoError(t, err)
+
+	client, closeFn := dialHealthClient(t, dt)
+	defer closeFn()
+
+	resp, err := client.Check(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.NoError(t, err)
+	assert.EqualValues(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.Status)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&observer.observed))
+}
+
+func TestStreamClientInterceptorDeliversStreamMessages(t *testing.T) {
+	dt, err := dynamic_timeout.New(
+		dynamic_timeout.WithMinTimeout(time.Millisecond),
+		dynamic_timeout.WithMaxTimeout(time.Second),
+	)
+	assert.NoError(t, err)
+
+	client, closeFn := dialHealthClient(t, dt)
+	defer closeFn()
+
+	stream, err := client.Watch(context.Background(), &grpc_health_v1.HealthCheckRequest{})
+	assert.NoError(t, err)
+
+	resp, err := stream.Recv()
+	assert.NoError(t, err)
+	assert.EqualValues(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.Status)
+}
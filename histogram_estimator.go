@@ -0,0 +1,128 @@
+package dynamic_timeout
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const histogramSubBuckets = 8
+
+// HistogramEstimator is an Estimator backed by a log-linear bucketed
+// histogram: each power-of-two range between min and max is subdivided into
+// histogramSubBuckets equally-sized linear buckets. It trades a small amount
+// of quantile precision for O(1) Observe/Quantile without the marker-position
+// bookkeeping of P2Estimator, and is exact enough for timeout purposes.
+type HistogramEstimator struct {
+	lock sync.Mutex
+
+	min time.Duration
+	max time.Duration
+
+	boundaries []time.Duration // len(counts)+1 bucket edges, boundaries[0] == min
+	counts     []uint64
+	total      uint64
+}
+
+// NewHistogramEstimator returns a HistogramEstimator covering [min, max).
+// Observations below min or at/above max are clamped into the first or last
+// bucket respectively.
+func NewHistogramEstimator(min, max time.Duration) *HistogramEstimator {
+	powers := int(math.Ceil(math.Log2(float64(max) / float64(min))))
+	if powers < 1 {
+		powers = 1
+	}
+	bucketCount := powers * histogramSubBuckets
+
+	boundaries := make([]time.Duration, bucketCount+1)
+	for p := 0; p < powers; p++ {
+		base := min << uint(p)
+		for s := 0; s < histogramSubBuckets; s++ {
+			boundaries[p*histogramSubBuckets+s] = base + base*time.Duration(s)/histogramSubBuckets
+		}
+	}
+	boundaries[bucketCount] = max
+
+	return &HistogramEstimator{
+		min:        min,
+		max:        max,
+		boundaries: boundaries,
+		counts:     make([]uint64, bucketCount),
+	}
+}
+
+// Observe implements Estimator.
+func (e *HistogramEstimator) Observe(responseTime time.Duration) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.counts[e.bucketIndex(responseTime)]++
+	e.total++
+}
+
+// Quantile implements Estimator.
+func (e *HistogramEstimator) Quantile(q float64) time.Duration {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.total == 0 {
+		return 0
+	}
+
+	target := uint64(math.Ceil(q * float64(e.total)))
+	if target == 0 {
+		target = 1
+	}
+
+	var cumulative uint64
+	for i, count := range e.counts {
+		cumulative += count
+		if cumulative >= target {
+			return e.boundaries[i+1]
+		}
+	}
+
+	return e.max
+}
+
+// forEachBucket calls fn once for every non-empty bucket, with the bucket's
+// upper edge (the representative value Quantile would return for an
+// observation landing in it) and the number of observations it holds. It lets
+// callers that need a bounded digest of the distribution (e.g. the
+// time-windowed store) avoid keeping every raw observation around.
+func (e *HistogramEstimator) forEachBucket(fn func(value time.Duration, count uint64)) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	for i, count := range e.counts {
+		if count > 0 {
+			fn(e.boundaries[i+1], count)
+		}
+	}
+}
+
+// Reset implements Estimator.
+func (e *HistogramEstimator) Reset() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	for i := range e.counts {
+		e.counts[i] = 0
+	}
+	e.total = 0
+}
+
+func (e *HistogramEstimator) bucketIndex(responseTime time.Duration) int {
+	if responseTime <= e.min {
+		return 0
+	}
+	if responseTime >= e.max {
+		return len(e.counts) - 1
+	}
+
+	lastBucket := len(e.counts) - 1
+	return sort.Search(lastBucket, func(i int) bool {
+		return e.boundaries[i+1] > responseTime
+	})
+}
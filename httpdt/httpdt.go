@@ -0,0 +1,124 @@
+// Package httpdt wires DynamicTimeout into an http.Client by deriving each
+// request's deadline from DynamicTimeout.GetTimeout and observing the actual
+// round-trip time back into it.
+package httpdt
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	dynamic_timeout "github.com/HFN/dynamic-timeout"
+)
+
+type (
+	roundTripper struct {
+		dt    *dynamic_timeout.DynamicTimeout
+		inner http.RoundTripper
+	}
+
+	keyedRoundTripper struct {
+		registry *dynamic_timeout.Registry
+		keyFunc  func(req *http.Request) string
+		inner    http.RoundTripper
+	}
+)
+
+// NewRoundTripper returns an http.RoundTripper that applies dt.GetTimeout()
+// as the deadline for every request it sends through inner, and calls
+// dt.Observe() with the elapsed round-trip time for both successful requests
+// and requests that hit that deadline. A nil inner defaults to
+// http.DefaultTransport.
+func NewRoundTripper(dt *dynamic_timeout.DynamicTimeout, inner http.RoundTripper) http.RoundTripper {
+	if inner == nil {
+		inner = http.DefaultTransport
+	}
+
+	return &roundTripper{dt: dt, inner: inner}
+}
+
+// NewKeyedRoundTripper is like NewRoundTripper, but looks up a DynamicTimeout
+// per request in registry, keyed by keyFunc(req), so that each host/path
+// gets its own adaptive budget.
+func NewKeyedRoundTripper(registry *dynamic_timeout.Registry, keyFunc func(req *http.Request) string, inner http.RoundTripper) http.RoundTripper {
+	if inner == nil {
+		inner = http.DefaultTransport
+	}
+
+	return &keyedRoundTripper{registry: registry, keyFunc: keyFunc, inner: inner}
+}
+
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	return roundTrip(req, rt.dt.GetTimeout, rt.dt.Observe, rt.inner)
+}
+
+func (rt *keyedRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	key := rt.keyFunc(req)
+
+	getTimeout := func() time.Duration { return rt.registry.GetTimeout(key) }
+	observe := func(d time.Duration) { rt.registry.Observe(key, d) }
+
+	return roundTrip(req, getTimeout, observe, rt.inner)
+}
+
+// roundTrip performs the request with getTimeout() as its deadline. The
+// deadline must stay in effect until the response body is fully read or
+// closed, not just until headers arrive, so on success it hands the body off
+// to timedBody, which observes the elapsed time and releases the deadline's
+// context once the body is done being read.
+func roundTrip(req *http.Request, getTimeout func() time.Duration, observe func(time.Duration), inner http.RoundTripper) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), getTimeout())
+
+	start := time.Now()
+	resp, err := inner.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		if errors.Is(err, context.DeadlineExceeded) {
+			observe(time.Since(start))
+		}
+		return resp, err
+	}
+
+	resp.Body = &timedBody{ReadCloser: resp.Body, start: start, cancel: cancel, observe: observe}
+
+	return resp, nil
+}
+
+// timedBody wraps a response body to observe the elapsed time and cancel the
+// deadline's context exactly once, whichever comes first between the body
+// being read to completion, closed, or erroring out. A read error other than
+// the deadline being exceeded cancels the context without an observation.
+type timedBody struct {
+	io.ReadCloser
+
+	start   time.Time
+	cancel  context.CancelFunc
+	observe func(time.Duration)
+	once    sync.Once
+}
+
+func (b *timedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err != nil {
+		b.finish(err)
+	}
+
+	return n, err
+}
+
+func (b *timedBody) Close() error {
+	b.finish(nil)
+	return b.ReadCloser.Close()
+}
+
+func (b *timedBody) finish(err error) {
+	b.once.Do(func() {
+		if err == nil || err == io.EOF || errors.Is(err, context.DeadlineExceeded) {
+			b.observe(time.Since(b.start))
+		}
+		b.cancel()
+	})
+}
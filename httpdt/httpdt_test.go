@@ -0,0 +1,116 @@
+package httpdt
+
+import (
+	dynamic_timeout "github.com/HFN/dynamic-timeout"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingObserver struct {
+	observed int32
+}
+
+func (o *countingObserver) OnObserve(time.Duration) {
+	atomic.AddInt32(&o.observed, 1)
+}
+
+func (o *countingObserver) OnGetTimeout(time.Duration, time.Duration) {}
+
+func TestRoundTripperObservesSuccessfulRequest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	observer := &countingObserver{}
+	dt, err := dynamic_timeout.New(
+		dynamic_timeout.WithMinTimeout(time.Millisecond),
+		dynamic_timeout.WithMaxTimeout(time.Second),
+		dynamic_timeout.WithObserver(observer),
+	)
+	assert.NoError(t, err)
+
+	client := &http.Client{Transport: NewRoundTripper(dt, nil)}
+
+	resp, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	assert.EqualValues(t, http.StatusOK, resp.StatusCode)
+
+	assert.NoError(t, resp.Body.Close())
+	assert.EqualValues(t, 1, atomic.LoadInt32(&observer.observed))
+}
+
+func TestRoundTripperAllowsReadingStreamedBodyAfterRoundTripReturns(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		flusher := w.(http.Flusher)
+
+		for i := 0; i < 3; i++ {
+			_, _ = w.Write([]byte("chunk"))
+			flusher.Flush()
+			time.Sleep(10 * time.Millisecond)
+		}
+	}))
+	defer server.Close()
+
+	dt, err := dynamic_timeout.New(
+		dynamic_timeout.WithMinTimeout(time.Millisecond),
+		dynamic_timeout.WithMaxTimeout(time.Second),
+	)
+	assert.NoError(t, err)
+
+	client := &http.Client{Transport: NewRoundTripper(dt, nil)}
+
+	resp, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.EqualValues(t, "chunkchunkchunk", string(body))
+}
+
+func TestRoundTripperObservesDeadlineExceeded(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	observer := &countingObserver{}
+	dt, err := dynamic_timeout.New(
+		dynamic_timeout.WithMinTimeout(time.Millisecond),
+		dynamic_timeout.WithMaxTimeout(5*time.Millisecond),
+		dynamic_timeout.WithObserver(observer),
+	)
+	assert.NoError(t, err)
+
+	client := &http.Client{Transport: NewRoundTripper(dt, nil)}
+
+	_, err = client.Get(server.URL)
+	assert.Error(t, err)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&observer.observed))
+}
+
+func TestKeyedRoundTripperUsesPerHostTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	registry, err := dynamic_timeout.NewRegistry()
+	assert.NoError(t, err)
+
+	client := &http.Client{Transport: NewKeyedRoundTripper(registry, func(req *http.Request) string {
+		return req.URL.Host
+	}, nil)}
+
+	resp, err := client.Get(server.URL)
+	assert.NoError(t, err)
+	assert.EqualValues(t, http.StatusOK, resp.StatusCode)
+	assert.EqualValues(t, 1, registry.Len())
+}
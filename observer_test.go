@@ -0,0 +1,45 @@
+package dynamic_timeout
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type recordingObserver struct {
+	observed []time.Duration
+	computed []time.Duration
+	clamped  []time.Duration
+}
+
+func (o *recordingObserver) OnObserve(responseTime time.Duration) {
+	o.observed = append(o.observed, responseTime)
+}
+
+func (o *recordingObserver) OnGetTimeout(computed time.Duration, clamped time.Duration) {
+	o.computed = append(o.computed, computed)
+	o.clamped = append(o.clamped, clamped)
+}
+
+func TestWithObserverReceivesCallbacks(t *testing.T) {
+	observer := &recordingObserver{}
+
+	dt, err := New(
+		WithMinTimeout(10),
+		WithMaxTimeout(20),
+		WithMaxHistory(1),
+		WithTimeoutFunc(func(responseTimeHistory []time.Duration) time.Duration {
+			return 30
+		}),
+		WithObserver(observer),
+	)
+	assert.NoError(t, err)
+
+	dt.Observe(5)
+	assert.EqualValues(t, []time.Duration{5}, observer.observed)
+
+	timeout := dt.GetTimeout()
+	assert.EqualValues(t, 20, timeout)
+	assert.EqualValues(t, []time.Duration{30}, observer.computed)
+	assert.EqualValues(t, []time.Duration{20}, observer.clamped)
+}
@@ -0,0 +1,153 @@
+package dynamic_timeout
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// P2Estimator is an Estimator that implements the P² algorithm (Jain & Chlamtac,
+// 1985) for online estimation of a single quantile. It tracks five markers
+// (min, p/2, p, (1+p)/2, max) and adjusts their positions on every
+// observation, giving O(1) time and constant memory regardless of how many
+// observations have been made, at the cost of only estimating the quantile
+// it was constructed with.
+type P2Estimator struct {
+	lock sync.Mutex
+
+	quantile float64
+
+	initial []time.Duration // buffer for the first 5 observations
+
+	n         [5]float64 // marker positions
+	desired   [5]float64 // desired marker positions
+	increment [5]float64 // desired position increments
+	heights   [5]float64 // marker heights
+}
+
+// NewP2Estimator returns a P2Estimator tracking the given quantile (in [0, 1]).
+// Quantile ignores its argument and always returns the estimate for this
+// quantile; it exists to satisfy the Estimator interface.
+func NewP2Estimator(quantile float64) *P2Estimator {
+	return &P2Estimator{
+		quantile: quantile,
+		initial:  make([]time.Duration, 0, 5),
+	}
+}
+
+// Observe implements Estimator.
+func (e *P2Estimator) Observe(responseTime time.Duration) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if len(e.initial) < 5 {
+		e.initial = append(e.initial, responseTime)
+		if len(e.initial) == 5 {
+			e.initialize()
+		}
+		return
+	}
+
+	x := float64(responseTime)
+
+	k := 0
+	switch {
+	case x < e.heights[0]:
+		e.heights[0] = x
+		k = 0
+	case x >= e.heights[4]:
+		e.heights[4] = x
+		k = 3
+	default:
+		for i := 0; i < 4; i++ {
+			if x < e.heights[i+1] {
+				k = i
+				break
+			}
+		}
+	}
+
+	for i := k + 1; i < 5; i++ {
+		e.n[i]++
+	}
+	for i := 0; i < 5; i++ {
+		e.desired[i] += e.increment[i]
+	}
+
+	for i := 1; i < 4; i++ {
+		d := e.desired[i] - e.n[i]
+		if (d >= 1 && e.n[i+1]-e.n[i] > 1) || (d <= -1 && e.n[i-1]-e.n[i] < -1) {
+			sign := 1.0
+			if d < 0 {
+				sign = -1.0
+			}
+
+			parabolic := e.parabolic(i, sign)
+			if e.heights[i-1] < parabolic && parabolic < e.heights[i+1] {
+				e.heights[i] = parabolic
+			} else {
+				e.heights[i] = e.linear(i, sign)
+			}
+			e.n[i] += sign
+		}
+	}
+}
+
+// Quantile implements Estimator. Its argument is ignored; it always returns
+// the estimate for the quantile this P2Estimator was constructed with.
+func (e *P2Estimator) Quantile(float64) time.Duration {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if len(e.initial) < 5 {
+		if len(e.initial) == 0 {
+			return 0
+		}
+		sorted := append([]time.Duration(nil), e.initial...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		index := int(e.quantile * float64(len(sorted)-1))
+		return sorted[index]
+	}
+
+	return time.Duration(e.heights[2])
+}
+
+// Reset implements Estimator.
+func (e *P2Estimator) Reset() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	e.initial = e.initial[:0]
+	e.n = [5]float64{}
+	e.desired = [5]float64{}
+	e.increment = [5]float64{}
+	e.heights = [5]float64{}
+}
+
+func (e *P2Estimator) initialize() {
+	sort.Slice(e.initial, func(i, j int) bool { return e.initial[i] < e.initial[j] })
+
+	for i, d := range e.initial {
+		e.heights[i] = float64(d)
+		e.n[i] = float64(i + 1)
+	}
+
+	p := e.quantile
+	e.desired = [5]float64{1, 1 + 2*p, 1 + 4*p, 3 + 2*p, 5}
+	e.increment = [5]float64{0, p / 2, p, (1 + p) / 2, 1}
+}
+
+func (e *P2Estimator) parabolic(i int, d float64) float64 {
+	qi, qim1, qip1 := e.heights[i], e.heights[i-1], e.heights[i+1]
+	ni, nim1, nip1 := e.n[i], e.n[i-1], e.n[i+1]
+
+	return qi + d/(nip1-nim1)*((ni-nim1+d)*(qip1-qi)/(nip1-ni)+
+		(nip1-ni-d)*(qi-qim1)/(ni-nim1))
+}
+
+func (e *P2Estimator) linear(i int, d float64) float64 {
+	if d > 0 {
+		return e.heights[i] + (e.heights[i+1]-e.heights[i])/(e.n[i+1]-e.n[i])
+	}
+	return e.heights[i] - (e.heights[i-1]-e.heights[i])/(e.n[i-1]-e.n[i])
+}
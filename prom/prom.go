@@ -0,0 +1,86 @@
+// Package prom implements a dynamic_timeout.Observer that exposes observed
+// response times, the latest computed timeout, and min/max clamp events as
+// Prometheus metrics.
+package prom
+
+import (
+	"time"
+
+	dynamic_timeout "github.com/HFN/dynamic-timeout"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Observer is a dynamic_timeout.Observer backed by Prometheus metrics. It
+// implements prometheus.Collector so it can be passed directly to a
+// prometheus.Registerer.
+type Observer struct {
+	minTimeout time.Duration
+	maxTimeout time.Duration
+
+	observedResponseTime prometheus.Histogram
+	currentTimeout       prometheus.Gauge
+	clampEvents          *prometheus.CounterVec
+}
+
+// NewObserver returns an Observer with response time buckets spanning
+// [minTimeout, maxTimeout]. namespace/subsystem are passed through to the
+// underlying prometheus.Opts.
+func NewObserver(namespace, subsystem string, minTimeout, maxTimeout time.Duration) *Observer {
+	return &Observer{
+		minTimeout: minTimeout,
+		maxTimeout: maxTimeout,
+
+		observedResponseTime: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "dynamic_timeout_observed_response_time_seconds",
+			Help:      "Response times observed by DynamicTimeout.Observe.",
+			Buckets:   prometheus.ExponentialBucketsRange(minTimeout.Seconds(), maxTimeout.Seconds(), 10),
+		}),
+		currentTimeout: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "dynamic_timeout_current_seconds",
+			Help:      "Timeout most recently returned by DynamicTimeout.GetTimeout.",
+		}),
+		clampEvents: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "dynamic_timeout_clamp_events_total",
+			Help:      "Number of GetTimeout calls whose computed value was clamped, by bound (min or max).",
+		}, []string{"bound"}),
+	}
+}
+
+// OnObserve implements dynamic_timeout.Observer.
+func (o *Observer) OnObserve(responseTime time.Duration) {
+	o.observedResponseTime.Observe(responseTime.Seconds())
+}
+
+// OnGetTimeout implements dynamic_timeout.Observer.
+func (o *Observer) OnGetTimeout(computed time.Duration, clamped time.Duration) {
+	o.currentTimeout.Set(clamped.Seconds())
+
+	if computed < clamped {
+		o.clampEvents.WithLabelValues("min").Inc()
+	} else if computed > clamped {
+		o.clampEvents.WithLabelValues("max").Inc()
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (o *Observer) Describe(ch chan<- *prometheus.Desc) {
+	o.observedResponseTime.Describe(ch)
+	o.currentTimeout.Describe(ch)
+	o.clampEvents.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (o *Observer) Collect(ch chan<- prometheus.Metric) {
+	o.observedResponseTime.Collect(ch)
+	o.currentTimeout.Collect(ch)
+	o.clampEvents.Collect(ch)
+}
+
+var _ dynamic_timeout.Observer = (*Observer)(nil)
+var _ prometheus.Collector = (*Observer)(nil)
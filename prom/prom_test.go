@@ -0,0 +1,28 @@
+package prom
+
+import (
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestObserverRecordsClampEvents(t *testing.T) {
+	o := NewObserver("test", "dt", 10*time.Millisecond, 100*time.Millisecond)
+
+	o.OnGetTimeout(5*time.Millisecond, 10*time.Millisecond)
+	o.OnGetTimeout(200*time.Millisecond, 100*time.Millisecond)
+	o.OnGetTimeout(50*time.Millisecond, 50*time.Millisecond)
+
+	assert.EqualValues(t, 1, testutil.ToFloat64(o.clampEvents.WithLabelValues("min")))
+	assert.EqualValues(t, 1, testutil.ToFloat64(o.clampEvents.WithLabelValues("max")))
+	assert.EqualValues(t, 0.05, testutil.ToFloat64(o.currentTimeout))
+}
+
+func TestObserverRecordsObservations(t *testing.T) {
+	o := NewObserver("test", "dt", 10*time.Millisecond, 100*time.Millisecond)
+
+	o.OnObserve(30 * time.Millisecond)
+
+	assert.EqualValues(t, 1, testutil.CollectAndCount(o.observedResponseTime))
+}
@@ -0,0 +1,265 @@
+package dynamic_timeout
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// FNV-1a 32-bit constants, inlined so shardIndex can hash the common key
+// kinds without the allocation of a hash.Hash32 per call.
+const (
+	fnvOffset32 = 2166136261
+	fnvPrime32  = 16777619
+)
+
+const (
+	defaultShardCount = 32
+	defaultMaxKeys    = 0
+)
+
+type (
+	// Registry maintains a separate DynamicTimeout per key, lazily created on
+	// first use (Observe or GetTimeout). It is intended for callers that talk to many distinct
+	// backends (hosts, routes, tenants, ...) and don't want a slow backend to
+	// drag down the timeout computed for a fast one.
+	Registry struct {
+		dtOptions []Option
+		maxKeys   int
+		keyTTL    time.Duration
+		shards    []*registryShard
+
+		// order tracks recency across all shards, so maxKeys/keyTTL are
+		// enforced as a single bound on the whole Registry rather than per
+		// shard. Guarded by orderLock. A shard lock may be taken while
+		// orderLock is held, but orderLock is never acquired while a shard
+		// lock is held, to avoid lock-ordering deadlocks.
+		orderLock sync.Mutex
+		order     *list.List
+	}
+
+	// RegistryOption is the type of constructor options for NewRegistry(...)
+	RegistryOption func(r *Registry)
+
+	registryEntry struct {
+		key        interface{}
+		shardIndex int
+		dt         *DynamicTimeout
+		lastAccess time.Time
+		element    *list.Element
+	}
+
+	registryShard struct {
+		lock  sync.Mutex
+		items map[interface{}]*registryEntry
+	}
+)
+
+// WithDynamicTimeoutOptions sets the Option slice used to lazily construct the
+// DynamicTimeout for each key. These are the same Option functions accepted by
+// New(...) (WithMinTimeout, WithMaxTimeout, WithMaxHistory, WithTimeoutFunc, ...).
+func WithDynamicTimeoutOptions(options ...Option) RegistryOption {
+	return func(r *Registry) {
+		r.dtOptions = options
+	}
+}
+
+// WithMaxKeys sets the maximum number of keys the Registry keeps around at
+// once, across all keys. Once the limit is reached, the least recently used
+// key is evicted to make room for a new one. Zero disables the limit; a
+// negative value makes NewRegistry return an error.
+func WithMaxKeys(maxKeys int) RegistryOption {
+	return func(r *Registry) {
+		r.maxKeys = maxKeys
+	}
+}
+
+// WithKeyTTL sets the duration after which a key that has not been observed
+// or queried is evicted. Zero disables TTL-based eviction; a negative value
+// makes NewRegistry return an error.
+func WithKeyTTL(ttl time.Duration) RegistryOption {
+	return func(r *Registry) {
+		r.keyTTL = ttl
+	}
+}
+
+// NewRegistry returns a new Registry. The per-key DynamicTimeout instances
+// are validated eagerly (using WithDynamicTimeoutOptions, or the defaults of
+// New(...) if none are given) so that a configuration mistake surfaces here
+// rather than on the first Observe/GetTimeout call.
+func NewRegistry(options ...RegistryOption) (*Registry, error) {
+	r := &Registry{
+		maxKeys: defaultMaxKeys,
+	}
+
+	for _, option := range options {
+		option(r)
+	}
+
+	if _, err := New(r.dtOptions...); err != nil {
+		return nil, err
+	}
+	if r.maxKeys < 0 {
+		return nil, errors.New("maxKeys is negative")
+	}
+	if r.keyTTL < 0 {
+		return nil, errors.New("keyTTL is negative")
+	}
+
+	r.shards = make([]*registryShard, defaultShardCount)
+	for index := range r.shards {
+		r.shards[index] = &registryShard{
+			items: make(map[interface{}]*registryEntry),
+		}
+	}
+	r.order = list.New()
+
+	return r, nil
+}
+
+// Observe observes the given responseTime for the DynamicTimeout belonging to
+// key, creating it first if this is the first time key is seen.
+func (r *Registry) Observe(key interface{}, responseTime time.Duration) {
+	r.entryFor(key).dt.Observe(responseTime)
+}
+
+// GetTimeout returns the current timeout for key, as computed by the
+// DynamicTimeout belonging to key.
+func (r *Registry) GetTimeout(key interface{}) time.Duration {
+	return r.entryFor(key).dt.GetTimeout()
+}
+
+// Len returns the number of keys currently tracked by the Registry.
+func (r *Registry) Len() int {
+	r.orderLock.Lock()
+	defer r.orderLock.Unlock()
+
+	return r.order.Len()
+}
+
+func (r *Registry) entryFor(key interface{}) *registryEntry {
+	shardIdx := shardIndex(key, len(r.shards))
+	shard := r.shards[shardIdx]
+
+	shard.lock.Lock()
+	entry, ok := shard.items[key]
+	if !ok {
+		dt, _ := New(r.dtOptions...)
+		entry = &registryEntry{key: key, shardIndex: shardIdx, dt: dt}
+		shard.items[key] = entry
+	}
+	shard.lock.Unlock()
+
+	r.orderLock.Lock()
+	if entry.element == nil {
+		entry.element = r.order.PushFront(entry)
+	} else {
+		r.order.MoveToFront(entry.element)
+	}
+	entry.lastAccess = time.Now()
+
+	r.evictExpiredLocked()
+	r.evictOverflowLocked()
+	r.orderLock.Unlock()
+
+	return entry
+}
+
+// evictExpiredLocked removes every entry across all shards whose last access
+// is older than keyTTL. Callers must hold orderLock.
+func (r *Registry) evictExpiredLocked() {
+	if r.keyTTL <= 0 {
+		return
+	}
+
+	deadline := time.Now().Add(-r.keyTTL)
+	for element := r.order.Back(); element != nil; {
+		entry := element.Value.(*registryEntry)
+		if entry.lastAccess.After(deadline) {
+			break
+		}
+
+		previous := element.Prev()
+		r.removeLocked(element, entry)
+		element = previous
+	}
+}
+
+// evictOverflowLocked removes the least recently used entries across all
+// shards until the Registry holds at most maxKeys entries. Callers must hold
+// orderLock.
+func (r *Registry) evictOverflowLocked() {
+	if r.maxKeys <= 0 {
+		return
+	}
+
+	for r.order.Len() > r.maxKeys {
+		oldest := r.order.Back()
+		if oldest == nil {
+			break
+		}
+
+		r.removeLocked(oldest, oldest.Value.(*registryEntry))
+	}
+}
+
+// removeLocked deletes entry from the global order list and its owning
+// shard's map. Callers must hold orderLock.
+func (r *Registry) removeLocked(element *list.Element, entry *registryEntry) {
+	r.order.Remove(element)
+
+	shard := r.shards[entry.shardIndex]
+	shard.lock.Lock()
+	delete(shard.items, entry.key)
+	shard.lock.Unlock()
+}
+
+// shardIndex picks a shard for key. The common key kinds (string, int, int32,
+// int64, uint, uint32 and uint64) are hashed directly, since a Registry is
+// typically keyed by host/route/tenant at high QPS and shouldn't pay for a
+// fmt.Sprintf/reflection round trip on every Observe/GetTimeout call just to
+// pick a shard; anything else falls back to hashing its %v representation.
+func shardIndex(key interface{}, shardCount int) int {
+	return int(hashKey(key)) % shardCount
+}
+
+func hashKey(key interface{}) uint32 {
+	switch k := key.(type) {
+	case string:
+		return fnv32aString(k)
+	case int:
+		return fnv32aUint64(uint64(k))
+	case int32:
+		return fnv32aUint64(uint64(k))
+	case int64:
+		return fnv32aUint64(uint64(k))
+	case uint:
+		return fnv32aUint64(uint64(k))
+	case uint32:
+		return fnv32aUint64(uint64(k))
+	case uint64:
+		return fnv32aUint64(k)
+	default:
+		return fnv32aString(fmt.Sprintf("%v", k))
+	}
+}
+
+func fnv32aString(s string) uint32 {
+	hash := uint32(fnvOffset32)
+	for i := 0; i < len(s); i++ {
+		hash ^= uint32(s[i])
+		hash *= fnvPrime32
+	}
+	return hash
+}
+
+func fnv32aUint64(v uint64) uint32 {
+	hash := uint32(fnvOffset32)
+	for i := 0; i < 8; i++ {
+		hash ^= uint32(byte(v >> (8 * i)))
+		hash *= fnvPrime32
+	}
+	return hash
+}
@@ -0,0 +1,61 @@
+package dynamic_timeout
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestRegistryObserveAndGetTimeoutPerKey(t *testing.T) {
+	r, err := NewRegistry(WithDynamicTimeoutOptions(
+		WithMinTimeout(10),
+		WithMaxTimeout(100),
+		WithMaxHistory(3),
+	))
+	assert.NoError(t, err)
+
+	r.Observe("fast", 1)
+	r.Observe("fast", 1)
+	r.Observe("fast", 1)
+	r.Observe("slow", 90)
+	r.Observe("slow", 90)
+	r.Observe("slow", 90)
+
+	assert.EqualValues(t, 10, r.GetTimeout("fast"))
+	assert.EqualValues(t, 100, r.GetTimeout("slow"))
+	assert.EqualValues(t, 2, r.Len())
+}
+
+func TestRegistryMaxKeysEvictsLeastRecentlyUsed(t *testing.T) {
+	r, err := NewRegistry(WithMaxKeys(1))
+	assert.NoError(t, err)
+
+	r.Observe("a", 1)
+	r.Observe("b", 1)
+
+	assert.EqualValues(t, 1, r.Len())
+}
+
+func TestRegistryKeyTTLEvictsStaleKeys(t *testing.T) {
+	r, err := NewRegistry(WithKeyTTL(time.Millisecond))
+	assert.NoError(t, err)
+
+	r.Observe("a", 1)
+	assert.EqualValues(t, 1, r.Len())
+
+	time.Sleep(5 * time.Millisecond)
+	r.Observe("b", 1)
+
+	assert.EqualValues(t, 1, r.Len())
+}
+
+func TestNewRegistryInvalidOptions(t *testing.T) {
+	_, err := NewRegistry(WithDynamicTimeoutOptions(WithMaxHistory(0)))
+	assert.Error(t, err)
+
+	_, err = NewRegistry(WithMaxKeys(-1))
+	assert.Error(t, err)
+
+	_, err = NewRegistry(WithKeyTTL(-1))
+	assert.Error(t, err)
+}
@@ -0,0 +1,220 @@
+package dynamic_timeout
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const defaultTimeWindowBuckets = 60
+
+type (
+	// Sample is a single observation (or, for a WithTimeWindow-backed store,
+	// a digest bucket standing in for possibly many observations) handed to
+	// a TimeOutFuncCtx, together with how long ago it was observed relative
+	// to the GetTimeout call that is requesting it.
+	Sample struct {
+		Value time.Duration
+		Age   time.Duration
+		// Count is the number of observations this sample represents. Zero
+		// is treated as 1, so Samples built by hand (e.g. in tests) without
+		// setting Count behave like one observation per entry.
+		Count uint64
+	}
+
+	// Samples is the set of observations passed to a TimeOutFuncCtx. Unlike
+	// the plain history slice given to TimeOutFunc, it also carries each
+	// sample's Age, so a custom func can weight recent observations higher.
+	Samples []Sample
+
+	// TimeOutFuncCtx is like TimeOutFunc, but for a WithTimeWindow-backed
+	// DynamicTimeout: it receives Samples instead of a plain history slice,
+	// letting it account for how old each observation is.
+	TimeOutFuncCtx func(samples Samples) time.Duration
+
+	// timeWindowedStore is a time-bucketed circular buffer: observations are
+	// grouped into defaultTimeWindowBuckets sub-buckets of window/N each, and
+	// a bucket is reset the first time it is revisited on a later pass
+	// through the ring. Age is tracked per-bucket rather than per-sample,
+	// trading a small amount of precision for bounded memory. Each bucket in
+	// turn keeps only a HistogramEstimator digest rather than every raw
+	// value, so memory and samples() cost stay bounded regardless of QPS.
+	timeWindowedStore struct {
+		window     time.Duration
+		bucketSize time.Duration
+		histMin    time.Duration
+		histMax    time.Duration
+
+		lock    sync.Mutex
+		buckets []timeWindowBucket
+	}
+
+	timeWindowBucket struct {
+		start     time.Time
+		histogram *HistogramEstimator
+	}
+)
+
+func newTimeWindowedStore(window time.Duration, numBuckets int, histMin, histMax time.Duration) *timeWindowedStore {
+	return &timeWindowedStore{
+		window:     window,
+		bucketSize: window / time.Duration(numBuckets),
+		histMin:    histMin,
+		histMax:    histMax,
+		buckets:    make([]timeWindowBucket, numBuckets),
+	}
+}
+
+func (s *timeWindowedStore) observe(responseTime time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	bucket := s.currentBucketLocked(time.Now())
+	bucket.histogram.Observe(responseTime)
+}
+
+func (s *timeWindowedStore) samples() Samples {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	now := time.Now()
+	cutoff := now.Add(-s.window)
+
+	var samples Samples
+	for i := range s.buckets {
+		bucket := &s.buckets[i]
+		if bucket.start.IsZero() || bucket.start.Before(cutoff) || bucket.histogram == nil {
+			continue
+		}
+
+		age := now.Sub(bucket.start)
+		bucket.histogram.forEachBucket(func(value time.Duration, count uint64) {
+			samples = append(samples, Sample{Value: value, Age: age, Count: count})
+		})
+	}
+
+	return samples
+}
+
+// currentBucketLocked returns the bucket for "now", resetting it first if it
+// last held observations from a previous pass through the ring.
+func (s *timeWindowedStore) currentBucketLocked(now time.Time) *timeWindowBucket {
+	slot := now.UnixNano() / int64(s.bucketSize)
+	index := int(slot % int64(len(s.buckets)))
+	bucket := &s.buckets[index]
+
+	slotStart := time.Unix(0, slot*int64(s.bucketSize))
+	if !bucket.start.Equal(slotStart) {
+		bucket.start = slotStart
+		if bucket.histogram == nil {
+			bucket.histogram = NewHistogramEstimator(s.histMin, s.histMax)
+		} else {
+			bucket.histogram.Reset()
+		}
+	}
+
+	return bucket
+}
+
+// WithTimeWindow switches the DynamicTimeout from the fixed-count history
+// ring to a time-bucketed store that only considers observations from the
+// last window, so a burst of slow responses stops influencing the timeout
+// once window has passed. Mutually exclusive with WithEstimator. Defaults
+// the timeout policy to "3 * p95 of samples in window"; use
+// WithTimeoutFuncCtx to customise it.
+func WithTimeWindow(window time.Duration) Option {
+	return func(dt *DynamicTimeout) {
+		dt.timeWindow = window
+		dt.timeWindowSet = true
+		if dt.timeoutFuncCtx == nil {
+			dt.timeoutFuncCtx = QuantileSamplesTimeoutFunc(0.95, 3)
+		}
+	}
+}
+
+// WithTimeoutFuncCtx sets a custom TimeOutFuncCtx to calculate timeout from
+// the Samples produced by a WithTimeWindow-backed store.
+func WithTimeoutFuncCtx(timeoutFuncCtx TimeOutFuncCtx) Option {
+	return func(dt *DynamicTimeout) {
+		dt.timeoutFuncCtx = timeoutFuncCtx
+	}
+}
+
+// QuantileSamplesTimeoutFunc returns a TimeOutFuncCtx that computes
+// multiplier * quantile(q) over the sample values (weighted by Count),
+// ignoring their age.
+func QuantileSamplesTimeoutFunc(q float64, multiplier float64) TimeOutFuncCtx {
+	return func(samples Samples) time.Duration {
+		if len(samples) == 0 {
+			return 0
+		}
+
+		sorted := make(Samples, len(samples))
+		copy(sorted, samples)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].Value < sorted[j].Value })
+
+		var total uint64
+		for _, sample := range sorted {
+			total += sampleWeight(sample)
+		}
+
+		target := uint64(math.Ceil(q * float64(total)))
+		if target == 0 {
+			target = 1
+		}
+
+		var cumulative uint64
+		for _, sample := range sorted {
+			cumulative += sampleWeight(sample)
+			if cumulative >= target {
+				return time.Duration(multiplier * float64(sample.Value))
+			}
+		}
+
+		return time.Duration(multiplier * float64(sorted[len(sorted)-1].Value))
+	}
+}
+
+// sampleWeight returns how many observations sample represents, treating an
+// unset Count (the zero value) as a single observation.
+func sampleWeight(sample Sample) uint64 {
+	if sample.Count == 0 {
+		return 1
+	}
+	return sample.Count
+}
+
+// ExpDecayTimeoutFunc returns a TimeOutFuncCtx that computes an EWMA-style
+// timeout: multiplier * the weighted quantile q of the sample values, where
+// each sample is weighted by exp(-age/halfLife), so recent observations
+// count more than older ones within the window.
+func ExpDecayTimeoutFunc(halfLife time.Duration, q float64, multiplier float64) TimeOutFuncCtx {
+	return func(samples Samples) time.Duration {
+		if len(samples) == 0 {
+			return 0
+		}
+
+		sorted := make(Samples, len(samples))
+		copy(sorted, samples)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i].Value < sorted[j].Value })
+
+		weights := make([]float64, len(sorted))
+		totalWeight := 0.0
+		for i, sample := range sorted {
+			weights[i] = float64(sampleWeight(sample)) * math.Exp(-float64(sample.Age)/float64(halfLife))
+			totalWeight += weights[i]
+		}
+
+		target := q * totalWeight
+		cumulative := 0.0
+		for i, sample := range sorted {
+			cumulative += weights[i]
+			if cumulative >= target {
+				return time.Duration(multiplier * float64(sample.Value))
+			}
+		}
+
+		return time.Duration(multiplier * float64(sorted[len(sorted)-1].Value))
+	}
+}
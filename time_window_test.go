@@ -0,0 +1,72 @@
+package dynamic_timeout
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestQuantileSamplesTimeoutFunc(t *testing.T) {
+	fn := QuantileSamplesTimeoutFunc(0.5, 2)
+
+	timeout := fn(Samples{
+		{Value: 10}, {Value: 20}, {Value: 30},
+	})
+
+	assert.EqualValues(t, 40, timeout)
+}
+
+func TestExpDecayTimeoutFuncFavoursRecentSamples(t *testing.T) {
+	fn := ExpDecayTimeoutFunc(time.Second, 0.5, 1)
+
+	recentHeavy := fn(Samples{
+		{Value: 100 * time.Millisecond, Age: 0},
+		{Value: 100 * time.Millisecond, Age: 0},
+		{Value: 10 * time.Millisecond, Age: 10 * time.Second},
+	})
+
+	assert.EqualValues(t, 100*time.Millisecond, recentHeavy)
+}
+
+func TestDynamicTimeoutWithTimeWindow(t *testing.T) {
+	dt, err := New(
+		WithMinTimeout(time.Millisecond),
+		WithMaxTimeout(time.Second),
+		WithTimeWindow(time.Minute),
+	)
+	assert.NoError(t, err)
+
+	for i := 0; i < 10; i++ {
+		dt.Observe(50 * time.Millisecond)
+	}
+
+	timeout := dt.GetTimeout()
+	assert.InDelta(t, 150*time.Millisecond, timeout, float64(20*time.Millisecond))
+}
+
+func TestDynamicTimeoutWithTimeWindowGetTimeoutBeforeObserve(t *testing.T) {
+	dt, err := New(
+		WithMinTimeout(time.Millisecond),
+		WithMaxTimeout(time.Second),
+		WithTimeWindow(time.Minute),
+	)
+	assert.NoError(t, err)
+
+	assert.EqualValues(t, time.Second, dt.GetTimeout())
+}
+
+func TestNewRejectsEstimatorAndTimeWindowTogether(t *testing.T) {
+	_, err := New(
+		WithEstimator(NewHistogramEstimator(time.Microsecond, 10*time.Second)),
+		WithTimeWindow(time.Minute),
+	)
+	assert.Error(t, err)
+}
+
+func TestNewRejectsNonPositiveTimeWindow(t *testing.T) {
+	_, err := New(WithTimeWindow(0))
+	assert.Error(t, err)
+
+	_, err = New(WithTimeWindow(-time.Second))
+	assert.Error(t, err)
+}